@@ -0,0 +1,111 @@
+/*
+ * Copyright (c) 2020, Jake Grogan
+ * All rights reserved.
+ *
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ *  * Redistributions of source code must retain the above copyright notice, this
+ *    list of conditions and the following disclaimer.
+ *
+ *  * Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ *
+ *  * Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from
+ *    this software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+ * DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+ * FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+ * DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+ * SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+ * CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+ * OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+ * OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package cluster
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// queuedBroadcast is one gossip message in flight: a cache delta
+// or a membership update, encoded and ready to piggyback onto the
+// next outgoing probe or ack.
+type queuedBroadcast struct {
+	payload   []byte
+	transmits int
+}
+
+// broadcastQueue holds every gossip message this node still owes
+// the rest of the cluster a retransmission of. Each message is
+// capped at RetransmitMult * ceil(log(N+1)) sends, N being the
+// current cluster size, so gossip cost stays sub-linear in
+// cluster size instead of broadcasting forever.
+type broadcastQueue struct {
+	mux            sync.Mutex
+	queue          []*queuedBroadcast
+	retransmitMult int
+	clusterSize    func() int
+}
+
+func newBroadcastQueue(retransmitMult int, clusterSize func() int) *broadcastQueue {
+	return &broadcastQueue{retransmitMult: retransmitMult, clusterSize: clusterSize}
+}
+
+// push enqueues a new message for gossip.
+func (q *broadcastQueue) push(payload []byte) {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+	q.queue = append(q.queue, &queuedBroadcast{payload: payload})
+}
+
+// retransmitLimit is how many times a single message may be sent
+// before it's considered to have reached the cluster.
+func (q *broadcastQueue) retransmitLimit() int {
+	n := q.clusterSize()
+	if n < 1 {
+		n = 1
+	}
+	return q.retransmitMult * int(math.Ceil(math.Log(float64(n+1))))
+}
+
+// take returns up to maxMsgs payloads to piggyback on an outgoing
+// packet, least-transmitted first so every message gets a fair
+// shot at reaching the cluster, and drops anything that has hit
+// its retransmit limit.
+func (q *broadcastQueue) take(maxMsgs int) [][]byte {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+
+	limit := q.retransmitLimit()
+
+	live := q.queue[:0]
+	for _, b := range q.queue {
+		if b.transmits < limit {
+			live = append(live, b)
+		}
+	}
+	q.queue = live
+
+	sort.Slice(q.queue, func(i, j int) bool { return q.queue[i].transmits < q.queue[j].transmits })
+
+	n := maxMsgs
+	if n > len(q.queue) {
+		n = len(q.queue)
+	}
+
+	out := make([][]byte, 0, n)
+	for i := 0; i < n; i++ {
+		q.queue[i].transmits++
+		out = append(out, q.queue[i].payload)
+	}
+	return out
+}
@@ -0,0 +1,457 @@
+/*
+ * Copyright (c) 2020, Jake Grogan
+ * All rights reserved.
+ *
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ *  * Redistributions of source code must retain the above copyright notice, this
+ *    list of conditions and the following disclaimer.
+ *
+ *  * Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ *
+ *  * Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from
+ *    this software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+ * DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+ * FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+ * DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+ * SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+ * CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+ * OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+ * OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package cluster
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ninadingole/GhostDB/store/lru"
+)
+
+// maxGossipBatch caps how many queued broadcasts are piggybacked
+// onto a single probe reply, so anti-entropy traffic can't crowd
+// out the probe/ack exchange it's riding along on.
+const maxGossipBatch = 8
+
+// Cluster wraps a string-keyed, []byte-valued lru.List with SWIM
+// membership and gossiped invalidation, turning a single
+// GhostDB instance into one member of a peer group that converges
+// on the same cache contents after inserts and removals. Each
+// delta is gossiped only until its retransmit limit is spent, and
+// there is no full-state exchange, so a delta whose retransmits
+// are used up before a partition heals does not reach peers on
+// the other side afterward.
+type Cluster struct {
+	cfg        Config
+	conn       *net.UDPConn
+	cache      *lru.List[string, []byte]
+	membership *membership
+	broadcasts *broadcastQueue
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+
+	pendingMux  sync.Mutex
+	pendingAcks map[string]chan struct{}
+}
+
+// New binds cfg.BindAddr, wires cache's eviction hook to gossip
+// invalidations, and starts the receive/probe/suspicion loops.
+// cache should not be mutated directly by callers afterward; use
+// Cluster's Insert/Remove so peers stay in sync.
+func New(cfg Config, cache *lru.List[string, []byte]) (*Cluster, error) {
+	cfg = cfg.withDefaults()
+
+	addr, err := net.ResolveUDPAddr("udp", cfg.BindAddr)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Cluster{
+		cfg:         cfg,
+		conn:        conn,
+		cache:       cache,
+		membership:  newMembership(cfg.BindAddr, cfg.BindAddr),
+		stopCh:      make(chan struct{}),
+		pendingAcks: make(map[string]chan struct{}),
+	}
+	c.broadcasts = newBroadcastQueue(cfg.RetransmitMult, c.membership.size)
+
+	cache.OnEvict = c.onLocalEvict
+
+	for _, seed := range cfg.SeedPeers {
+		c.membership.upsert(seed, seed, statusAlive, 1)
+		go c.sendTo(seed, kindProbe, probeMsg{From: cfg.BindAddr})
+	}
+
+	c.wg.Add(3)
+	go c.recvLoop()
+	go c.probeLoop()
+	go c.suspicionLoop()
+
+	return c, nil
+}
+
+// Close stops the gossip/probe loops and releases the socket.
+func (c *Cluster) Close() error {
+	close(c.stopCh)
+	err := c.conn.Close()
+	c.wg.Wait()
+	return err
+}
+
+// Insert writes key locally and gossips the write to every peer.
+func (c *Cluster) Insert(key string, value []byte, ttl int64) (*lru.Node[string, []byte], error) {
+	if existing, ok := lru.Get(c.cache, key); ok {
+		if _, err := lru.RemoveNode(c.cache, existing, lru.EvictReplaced); err != nil {
+			return nil, err
+		}
+	}
+
+	node, err := lru.Insert(c.cache, key, value, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	c.broadcastDelta(Entry{Key: key, Value: value, CreatedAt: node.CreatedAt})
+	return node, nil
+}
+
+// Remove deletes key locally. The resulting tombstone is gossiped
+// to every peer by onLocalEvict, cache's lru.OnEvict hook, which
+// is the single place removal-driven broadcasts happen regardless
+// of whether the removal was this call, a capacity eviction, or a
+// TTL sweep.
+func (c *Cluster) Remove(key string) error {
+	node, ok := lru.Get(c.cache, key)
+	if !ok {
+		return errors.New("cluster: key not found")
+	}
+	_, err := lru.RemoveNode(c.cache, node, lru.EvictExplicit)
+	return err
+}
+
+func (c *Cluster) broadcastDelta(e Entry) {
+	payload, err := encode(kindDelta, deltaMsg{Entry: e})
+	if err != nil {
+		return
+	}
+	c.broadcasts.push(payload)
+}
+
+// onLocalEvict is cache's lru.OnEvict hook. A real departure from
+// the cache (capacity, TTL, or an explicit remove, including one
+// made by Cluster.Remove) is gossiped as a tombstone so peers drop
+// their copy too. EvictReplaced is an internal relocation, not a
+// departure, so it's not gossiped.
+func (c *Cluster) onLocalEvict(node *lru.Node[string, []byte], reason lru.EvictReason) {
+	if reason == lru.EvictReplaced {
+		return
+	}
+	c.broadcastDelta(Entry{Key: node.Key, Tombstone: true, CreatedAt: time.Now().Unix()})
+}
+
+// applyDelta resolves a gossiped Entry against whatever this node
+// currently has for that key via cfg.Delegate, and applies the
+// winner. Ties and stale deltas are no-ops. It reports whether the
+// local cache changed, so the caller only re-gossips deltas that
+// were news to this node.
+func (c *Cluster) applyDelta(remote Entry) bool {
+	var local Entry
+	existing, ok := lru.Get(c.cache, remote.Key)
+	if ok {
+		local = Entry{Key: remote.Key, Value: existing.Value, CreatedAt: existing.CreatedAt}
+	}
+
+	winner := c.cfg.Delegate.Resolve(local, remote)
+	if winner.Tombstone == local.Tombstone && winner.CreatedAt == local.CreatedAt && bytes.Equal(winner.Value, local.Value) {
+		return false
+	}
+
+	if ok {
+		if _, err := lru.RemoveNode(c.cache, existing, lru.EvictReplaced); err != nil {
+			return false
+		}
+	}
+	if winner.Tombstone {
+		return ok
+	}
+
+	_, _ = lru.Insert(c.cache, remote.Key, winner.Value, -1)
+	return true
+}
+
+func (c *Cluster) probeLoop() {
+	defer c.wg.Done()
+	ticker := time.NewTicker(c.cfg.ProbeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stopCh:
+			return
+		case <-ticker.C:
+			c.probeRandomPeer()
+		}
+	}
+}
+
+func (c *Cluster) probeRandomPeer() {
+	addrs := c.membership.aliveAddrs()
+	if len(addrs) == 0 {
+		return
+	}
+	target := addrs[rand.Intn(len(addrs))]
+
+	if c.sendProbeAndWaitAck(target) {
+		return
+	}
+	if c.indirectProbe(target, addrs) {
+		return
+	}
+
+	if c.membership.upsert(target, target, statusSuspect, 1) {
+		c.gossipMembership(target, target, statusSuspect, 1)
+	}
+}
+
+func (c *Cluster) sendProbeAndWaitAck(target string) bool {
+	ch := c.awaitAck(target)
+	defer c.forgetAck(target)
+
+	c.sendTo(target, kindProbe, probeMsg{From: c.cfg.BindAddr})
+
+	select {
+	case <-ch:
+		return true
+	case <-time.After(c.cfg.ProbeTimeout):
+		return false
+	}
+}
+
+// indirectProbe asks cfg.IndirectProbes other peers to probe
+// target on this node's behalf, for the case where the direct
+// path to target is down but target itself is still alive.
+func (c *Cluster) indirectProbe(target string, allAddrs []string) bool {
+	helpers := pickHelpers(allAddrs, target, c.cfg.IndirectProbes)
+	if len(helpers) == 0 {
+		return false
+	}
+
+	ch := c.awaitAck(target)
+	defer c.forgetAck(target)
+
+	for _, helper := range helpers {
+		c.sendTo(helper, kindIndirectProbe, indirectProbeMsg{From: c.cfg.BindAddr, Target: target})
+	}
+
+	select {
+	case <-ch:
+		return true
+	case <-time.After(c.cfg.ProbeTimeout):
+		return false
+	}
+}
+
+func (c *Cluster) relayIndirectProbe(m indirectProbeMsg) {
+	if c.sendProbeAndWaitAck(m.Target) {
+		c.sendTo(m.From, kindAck, ackMsg{From: m.Target})
+	}
+}
+
+func (c *Cluster) suspicionLoop() {
+	defer c.wg.Done()
+	ticker := time.NewTicker(c.cfg.SuspicionTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stopCh:
+			return
+		case <-ticker.C:
+			for _, name := range c.membership.expireSuspects(c.cfg.SuspicionTimeout) {
+				c.gossipMembership(name, name, statusDead, 1)
+			}
+		}
+	}
+}
+
+func (c *Cluster) gossipMembership(name, addr string, s status, incarnation uint64) {
+	payload, err := encode(kindMembershipUpdate, membershipUpdateMsg{Name: name, Addr: addr, Status: s, Incarnation: incarnation})
+	if err != nil {
+		return
+	}
+	c.broadcasts.push(payload)
+}
+
+func (c *Cluster) recvLoop() {
+	defer c.wg.Done()
+
+	buf := make([]byte, 64*1024)
+	for {
+		select {
+		case <-c.stopCh:
+			return
+		default:
+		}
+
+		_ = c.conn.SetReadDeadline(time.Now().Add(500 * time.Millisecond))
+		n, _, err := c.conn.ReadFromUDP(buf)
+		if err != nil {
+			continue
+		}
+
+		e, err := decodeEnvelope(buf[:n])
+		if err != nil {
+			continue
+		}
+		c.handle(e)
+	}
+}
+
+func (c *Cluster) handle(e envelope) {
+	switch e.Kind {
+	case kindProbe:
+		var m probeMsg
+		if json.Unmarshal(e.Raw, &m) != nil {
+			return
+		}
+		c.membership.upsert(m.From, m.From, statusAlive, 1)
+		c.sendTo(m.From, kindAck, ackMsg{From: c.cfg.BindAddr})
+		c.flushGossipTo(m.From)
+
+	case kindAck:
+		var m ackMsg
+		if json.Unmarshal(e.Raw, &m) != nil {
+			return
+		}
+		c.membership.upsert(m.From, m.From, statusAlive, 1)
+		c.signalAck(m.From)
+
+	case kindIndirectProbe:
+		var m indirectProbeMsg
+		if json.Unmarshal(e.Raw, &m) != nil {
+			return
+		}
+		go c.relayIndirectProbe(m)
+
+	case kindDelta:
+		var m deltaMsg
+		if json.Unmarshal(e.Raw, &m) != nil {
+			return
+		}
+		if !c.applyDelta(m.Entry) {
+			return
+		}
+		payload, err := encode(kindDelta, m)
+		if err == nil {
+			c.broadcasts.push(payload)
+		}
+
+	case kindMembershipUpdate:
+		var m membershipUpdateMsg
+		if json.Unmarshal(e.Raw, &m) != nil {
+			return
+		}
+		if m.Name == c.cfg.BindAddr {
+			// Someone is gossiping our own death; refute it.
+			c.membership.refuteSelf(m.Incarnation)
+			return
+		}
+		if c.membership.upsert(m.Name, m.Addr, m.Status, m.Incarnation) {
+			payload, err := encode(kindMembershipUpdate, m)
+			if err == nil {
+				c.broadcasts.push(payload)
+			}
+		}
+	}
+}
+
+func (c *Cluster) sendTo(addr string, kind messageKind, v interface{}) {
+	payload, err := encode(kind, v)
+	if err != nil {
+		return
+	}
+	raddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return
+	}
+	_, _ = c.conn.WriteToUDP(payload, raddr)
+}
+
+// flushGossipTo piggybacks this node's pending broadcasts onto
+// its reply to addr's probe, which is how anti-entropy state
+// rides along probe traffic instead of needing its own packets.
+func (c *Cluster) flushGossipTo(addr string) {
+	raddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return
+	}
+	for _, payload := range c.broadcasts.take(maxGossipBatch) {
+		_, _ = c.conn.WriteToUDP(payload, raddr)
+	}
+}
+
+func (c *Cluster) awaitAck(target string) chan struct{} {
+	ch := make(chan struct{}, 1)
+	c.pendingMux.Lock()
+	c.pendingAcks[target] = ch
+	c.pendingMux.Unlock()
+	return ch
+}
+
+func (c *Cluster) forgetAck(target string) {
+	c.pendingMux.Lock()
+	delete(c.pendingAcks, target)
+	c.pendingMux.Unlock()
+}
+
+func (c *Cluster) signalAck(from string) {
+	c.pendingMux.Lock()
+	ch, ok := c.pendingAcks[from]
+	c.pendingMux.Unlock()
+	if !ok {
+		return
+	}
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// pickHelpers picks up to k addresses from all, excluding target,
+// in random order, for an indirect probe.
+func pickHelpers(all []string, target string, k int) []string {
+	candidates := make([]string, 0, len(all))
+	for _, a := range all {
+		if a != target {
+			candidates = append(candidates, a)
+		}
+	}
+	rand.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+
+	if k > len(candidates) {
+		k = len(candidates)
+	}
+	return candidates[:k]
+}
@@ -0,0 +1,92 @@
+/*
+ * Copyright (c) 2020, Jake Grogan
+ * All rights reserved.
+ *
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ *  * Redistributions of source code must retain the above copyright notice, this
+ *    list of conditions and the following disclaimer.
+ *
+ *  * Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ *
+ *  * Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from
+ *    this software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+ * DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+ * FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+ * DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+ * SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+ * CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+ * OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+ * OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package cluster
+
+import (
+	"testing"
+
+	"github.com/ninadingole/GhostDB/store/lru"
+)
+
+func TestLastWriteWinsResolve(t *testing.T) {
+	older := Entry{Key: "k", Value: []byte("older"), CreatedAt: 1}
+	newer := Entry{Key: "k", Value: []byte("newer"), CreatedAt: 2}
+
+	if got := (LastWriteWins{}).Resolve(older, newer); string(got.Value) != "newer" {
+		t.Fatalf("expected the later write to win, got %q", got.Value)
+	}
+	if got := (LastWriteWins{}).Resolve(newer, older); string(got.Value) != "newer" {
+		t.Fatalf("expected the later write to win regardless of argument order, got %q", got.Value)
+	}
+	if got := (LastWriteWins{}).Resolve(older, older); string(got.Value) != "older" {
+		t.Fatalf("expected an exact tie to keep local, got %q", got.Value)
+	}
+}
+
+// TestApplyDeltaConverges exercises the convergence behavior the
+// request asked Delegate.Resolve to guarantee: a stale remote
+// delta must lose to the local write, a newer remote delta must
+// win and replace it, and a newer remote tombstone must remove the
+// key entirely.
+func TestApplyDeltaConverges(t *testing.T) {
+	cache := lru.InitList[string, []byte](lru.PolicyLRU, 0)
+
+	c, err := New(Config{BindAddr: "127.0.0.1:0"}, cache)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer c.Close()
+
+	if _, err := c.Insert("k", []byte("local"), -1); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	local, ok := lru.Get(cache, "k")
+	if !ok {
+		t.Fatalf("expected k to be resident after Insert")
+	}
+
+	c.applyDelta(Entry{Key: "k", Value: []byte("stale-remote"), CreatedAt: local.CreatedAt - 100})
+	node, ok := lru.Get(cache, "k")
+	if !ok || string(node.Value) != "local" {
+		t.Fatalf("expected a stale remote delta to lose to the local write, got %q", node.Value)
+	}
+
+	c.applyDelta(Entry{Key: "k", Value: []byte("fresh-remote"), CreatedAt: local.CreatedAt + 100})
+	node, ok = lru.Get(cache, "k")
+	if !ok || string(node.Value) != "fresh-remote" {
+		t.Fatalf("expected a newer remote delta to win, got %q", node.Value)
+	}
+
+	c.applyDelta(Entry{Key: "k", Tombstone: true, CreatedAt: local.CreatedAt + 200})
+	if _, ok := lru.Get(cache, "k"); ok {
+		t.Fatalf("expected a newer remote tombstone to remove k")
+	}
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright (c) 2020, Jake Grogan
+ * All rights reserved.
+ *
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ *  * Redistributions of source code must retain the above copyright notice, this
+ *    list of conditions and the following disclaimer.
+ *
+ *  * Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ *
+ *  * Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from
+ *    this software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+ * DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+ * FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+ * DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+ * SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+ * CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+ * OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+ * OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+ */
+
+// Package cluster wraps a store/lru cache with a SWIM-style
+// gossip layer, so a group of GhostDB instances can discover each
+// other and invalidate each other's entries without a separate
+// coordination service.
+package cluster
+
+import "time"
+
+// Config controls how a Cluster binds, discovers peers, and
+// paces its gossip.
+type Config struct {
+	// BindAddr is the "host:port" this node listens on for both
+	// probes and gossip, e.g. "0.0.0.0:7946".
+	BindAddr string
+
+	// SeedPeers are "host:port" addresses of already-running
+	// peers to join through. An empty SeedPeers starts a new,
+	// single-node cluster that others can join later.
+	SeedPeers []string
+
+	// GossipInterval is filled in by withDefaults but is not read
+	// by Cluster: queued broadcasts (inserts, removals, membership
+	// updates) are sent only alongside this node's replies to
+	// incoming probes, up to maxGossipBatch per reply.
+	GossipInterval time.Duration
+
+	// ProbeInterval is how often this node probes a random peer
+	// to check it is still alive.
+	ProbeInterval time.Duration
+
+	// ProbeTimeout is how long a direct probe waits for an ack
+	// before falling back to indirect probes through other peers.
+	ProbeTimeout time.Duration
+
+	// SuspicionTimeout is how long a peer stays Suspect, with no
+	// refutation, before this node marks it Dead and broadcasts
+	// that verdict.
+	SuspicionTimeout time.Duration
+
+	// IndirectProbes is how many other peers are asked to probe a
+	// non-responsive peer on this node's behalf before it is
+	// marked Suspect.
+	IndirectProbes int
+
+	// RetransmitMult scales how many times a broadcast is
+	// retransmitted: transmits = RetransmitMult * ceil(log(N+1)),
+	// where N is the current cluster size.
+	RetransmitMult int
+
+	// Delegate resolves conflicting deltas for the same key seen
+	// from two different peers. Defaults to LastWriteWins if nil.
+	Delegate Delegate
+}
+
+// withDefaults fills in zero-valued fields with the package's
+// defaults, mirroring typical SWIM/memberlist tunables.
+func (c Config) withDefaults() Config {
+	if c.GossipInterval == 0 {
+		c.GossipInterval = 200 * time.Millisecond
+	}
+	if c.ProbeInterval == 0 {
+		c.ProbeInterval = time.Second
+	}
+	if c.ProbeTimeout == 0 {
+		c.ProbeTimeout = 500 * time.Millisecond
+	}
+	if c.SuspicionTimeout == 0 {
+		c.SuspicionTimeout = 5 * time.Second
+	}
+	if c.IndirectProbes == 0 {
+		c.IndirectProbes = 3
+	}
+	if c.RetransmitMult == 0 {
+		c.RetransmitMult = 4
+	}
+	if c.Delegate == nil {
+		c.Delegate = LastWriteWins{}
+	}
+	return c
+}
@@ -0,0 +1,68 @@
+/*
+ * Copyright (c) 2020, Jake Grogan
+ * All rights reserved.
+ *
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ *  * Redistributions of source code must retain the above copyright notice, this
+ *    list of conditions and the following disclaimer.
+ *
+ *  * Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ *
+ *  * Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from
+ *    this software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+ * DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+ * FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+ * DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+ * SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+ * CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+ * OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+ * OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package cluster
+
+// Entry is the gossiped form of a cache entry: just enough to
+// apply or discard a delta, independent of the lru.Node shape the
+// local cache stores it as.
+type Entry struct {
+	Key       string
+	Value     []byte
+	CreatedAt int64
+
+	// Tombstone marks a removal rather than an insert/update. A
+	// tombstone still carries CreatedAt (the time of the removal)
+	// so Delegate.Resolve can order it against concurrent
+	// inserts the usual way.
+	Tombstone bool
+}
+
+// Delegate resolves two Entry values seen for the same key,
+// arriving from different peers (or from a local write racing a
+// remote one). Resolve must be commutative and idempotent:
+// applying the same pair of entries in either order, any number
+// of times, must converge on the same result, since gossip
+// delivers every delta at least once but in no particular order.
+type Delegate interface {
+	Resolve(local, remote Entry) Entry
+}
+
+// LastWriteWins is the default Delegate: the entry with the later
+// CreatedAt wins; exact ties keep local, which is an arbitrary
+// but consistent tiebreak every node applies the same way.
+type LastWriteWins struct{}
+
+func (LastWriteWins) Resolve(local, remote Entry) Entry {
+	if remote.CreatedAt > local.CreatedAt {
+		return remote
+	}
+	return local
+}
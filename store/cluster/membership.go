@@ -0,0 +1,184 @@
+/*
+ * Copyright (c) 2020, Jake Grogan
+ * All rights reserved.
+ *
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ *  * Redistributions of source code must retain the above copyright notice, this
+ *    list of conditions and the following disclaimer.
+ *
+ *  * Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ *
+ *  * Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from
+ *    this software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+ * DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+ * FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+ * DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+ * SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+ * CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+ * OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+ * OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package cluster
+
+import (
+	"sync"
+	"time"
+)
+
+// status is where a peer sits in the SWIM failure-detection state
+// machine: Alive peers answer probes, Suspect peers have missed
+// one and are on the clock to refute it, Dead peers have run out
+// that clock.
+type status int
+
+const (
+	statusAlive status = iota
+	statusSuspect
+	statusDead
+)
+
+// member is one peer's membership record. Incarnation is the
+// peer's own generation counter: a peer refutes a Suspect
+// accusation by gossiping a higher Incarnation for itself, the
+// same mechanism SWIM and memberlist use to outrun a stale
+// rumor of its death.
+type member struct {
+	Name        string
+	Addr        string
+	Status      status
+	Incarnation uint64
+
+	// suspectedAt is when Status last became statusSuspect; it
+	// gates the SuspicionTimeout.
+	suspectedAt time.Time
+}
+
+// membership is this node's view of the cluster. Every Cluster
+// owns exactly one; it is mutated by the probe loop, by incoming
+// gossip, and read by the broadcast queue to size retransmits.
+type membership struct {
+	mux  sync.RWMutex
+	self *member
+
+	// peers excludes self; keyed by Name for O(1) lookup during
+	// probes and incoming state merges.
+	peers map[string]*member
+}
+
+func newMembership(selfName, selfAddr string) *membership {
+	return &membership{
+		self:  &member{Name: selfName, Addr: selfAddr, Status: statusAlive, Incarnation: 1},
+		peers: make(map[string]*member),
+	}
+}
+
+// size is the number of nodes this node believes are in the
+// cluster, including itself. The broadcast queue uses it to
+// size its retransmit limit.
+func (m *membership) size() int {
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+	return len(m.peers) + 1
+}
+
+// aliveAddrs returns the bind addresses of every peer this node
+// has not marked Dead (Alive or Suspect), for the probe loop to
+// pick from.
+func (m *membership) aliveAddrs() []string {
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+
+	addrs := make([]string, 0, len(m.peers))
+	for _, p := range m.peers {
+		if p.Status != statusDead {
+			addrs = append(addrs, p.Addr)
+		}
+	}
+	return addrs
+}
+
+// upsert merges an observed (name, addr, status, incarnation)
+// tuple into the membership, following SWIM's precedence rule: a
+// higher incarnation always wins; at equal incarnation, Dead beats
+// Suspect beats Alive (bad news spreads, stale good news doesn't
+// overwrite it). It reports whether anything changed, so the
+// caller knows whether to re-broadcast the update.
+func (m *membership) upsert(name, addr string, s status, incarnation uint64) bool {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if name == m.self.Name {
+		// Only a higher incarnation naming us Suspect/Dead forces
+		// a refutation; the caller does the actual re-broadcast.
+		return incarnation > m.self.Incarnation && s != statusAlive
+	}
+
+	p, ok := m.peers[name]
+	if !ok {
+		m.peers[name] = &member{Name: name, Addr: addr, Status: s, Incarnation: incarnation, suspectedAt: time.Now()}
+		return true
+	}
+
+	if incarnation < p.Incarnation {
+		return false
+	}
+	if incarnation == p.Incarnation && rank(s) <= rank(p.Status) {
+		return false
+	}
+
+	p.Status = s
+	p.Incarnation = incarnation
+	if s == statusSuspect {
+		p.suspectedAt = time.Now()
+	}
+	return true
+}
+
+// rank orders statuses for the "bad news wins" tie-break above.
+func rank(s status) int {
+	switch s {
+	case statusDead:
+		return 2
+	case statusSuspect:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// refuteSelf bumps this node's own incarnation past challenged,
+// so the next gossip round broadcasts proof of life.
+func (m *membership) refuteSelf(challenged uint64) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	if challenged >= m.self.Incarnation {
+		m.self.Incarnation = challenged + 1
+	}
+}
+
+// expireSuspects walks every Suspect peer and marks the ones past
+// SuspicionTimeout as Dead, returning their names so the caller
+// can broadcast the verdict.
+func (m *membership) expireSuspects(timeout time.Duration) []string {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	var expired []string
+	for name, p := range m.peers {
+		if p.Status == statusSuspect && time.Since(p.suspectedAt) >= timeout {
+			p.Status = statusDead
+			expired = append(expired, name)
+		}
+	}
+	return expired
+}
@@ -0,0 +1,99 @@
+/*
+ * Copyright (c) 2020, Jake Grogan
+ * All rights reserved.
+ *
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ *  * Redistributions of source code must retain the above copyright notice, this
+ *    list of conditions and the following disclaimer.
+ *
+ *  * Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ *
+ *  * Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from
+ *    this software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+ * DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+ * FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+ * DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+ * SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+ * CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+ * OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+ * OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package cluster
+
+import "encoding/json"
+
+// messageKind is the first thing decoded off an incoming UDP
+// packet, so the receive loop knows which struct to unmarshal the
+// rest into.
+type messageKind int
+
+const (
+	kindProbe messageKind = iota
+	kindAck
+	kindIndirectProbe
+	kindDelta
+	kindMembershipUpdate
+)
+
+// envelope wraps every message this package puts on the wire. Raw
+// is the kind-specific payload, JSON-encoded separately so
+// envelope itself never needs to know every message shape.
+type envelope struct {
+	Kind messageKind
+	Raw  json.RawMessage
+}
+
+type probeMsg struct {
+	From string
+}
+
+type ackMsg struct {
+	From string
+}
+
+// indirectProbeMsg asks its recipient to probe Target on From's
+// behalf; if Target acks, the recipient sends From an ack naming
+// Target. A failed relay sends nothing back.
+type indirectProbeMsg struct {
+	From   string
+	Target string
+}
+
+// deltaMsg is a gossiped cache mutation: an insert/update or a
+// tombstoned removal, per Entry.Tombstone.
+type deltaMsg struct {
+	Entry Entry
+}
+
+// membershipUpdateMsg is a gossiped SWIM state transition for one
+// peer (Suspect, Dead, or a refutation back to Alive).
+type membershipUpdateMsg struct {
+	Name        string
+	Addr        string
+	Status      status
+	Incarnation uint64
+}
+
+func encode(kind messageKind, v interface{}) ([]byte, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(envelope{Kind: kind, Raw: raw})
+}
+
+func decodeEnvelope(b []byte) (envelope, error) {
+	var e envelope
+	err := json.Unmarshal(b, &e)
+	return e, err
+}
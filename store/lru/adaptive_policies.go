@@ -0,0 +1,348 @@
+/*
+ * Copyright (c) 2020, Jake Grogan
+ * All rights reserved.
+ *
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ *  * Redistributions of source code must retain the above copyright notice, this
+ *    list of conditions and the following disclaimer.
+ *
+ *  * Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ *
+ *  * Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from
+ *    this software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+ * DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+ * FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+ * DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+ * SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+ * CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+ * OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+ * OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package lru
+
+import (
+	"errors"
+	"sync"
+)
+
+// adaptiveState holds whichever of the 2Q or ARC sub-list
+// structures a List's Policy needs. Exactly one of twoQ/arc is
+// populated, matching List.Policy.
+type adaptiveState[K comparable, V any] struct {
+	twoQ *twoQState[K, V]
+	arc  *arcState[K, V]
+}
+
+// twoQState is the 2Q replacement algorithm's bookkeeping: a1In
+// is a small FIFO of entries that have only been seen once, a1Out
+// is a ghost FIFO of the keys evicted from a1In, and am is the
+// main LRU holding everything promoted out of a1In/a1Out. index
+// maps a key to its resident node in a1In or am, so Access can
+// tell a hit from a miss without the caller tracking it.
+type twoQState[K comparable, V any] struct {
+	mux sync.Mutex
+
+	a1In  *List[K, V]
+	a1Out *List[K, V]
+	am    *List[K, V]
+
+	a1InCap  int32
+	a1OutCap int32
+	amCap    int32
+
+	// index maps a key to the sub-list it currently lives in
+	// (a1In or am) plus its node there, so a hit can be told
+	// apart from a miss, and promoted into am's LRU order.
+	index map[K]residentEntry[K, V]
+}
+
+// residentEntry is where Access found a key: which sub-list owns
+// it and the node itself.
+type residentEntry[K comparable, V any] struct {
+	list *List[K, V]
+	node *Node[K, V]
+}
+
+// newTwoQState sizes a1In at 25% of capacity and a1Out at 50%,
+// per the original 2Q paper's defaults. am gets whatever capacity
+// is left over from a1In, and is evicted from on overflow exactly
+// like a1In/a1Out are.
+func newTwoQState[K comparable, V any](capacity int32) *twoQState[K, V] {
+	return &twoQState[K, V]{
+		a1In:     InitList[K, V](PolicyLRU, 0),
+		a1Out:    InitList[K, V](PolicyLRU, 0),
+		am:       InitList[K, V](PolicyLRU, 0),
+		a1InCap:  capacity / 4,
+		a1OutCap: capacity / 2,
+		amCap:    capacity - capacity/4,
+		index:    make(map[K]residentEntry[K, V]),
+	}
+}
+
+// Access records a request for key under a 2Q or ARC list,
+// inserting value if key is not already resident. It is the
+// single entry point for both adaptive policies: unlike
+// PolicyLRU/PolicySIEVE, promotion out of a ghost list changes
+// which sub-list a key lives in, so Touch/Evict's node-only
+// signatures aren't enough here.
+func Access[K comparable, V any](ll *List[K, V], key K, value V, ttl int64) (*Node[K, V], error) {
+	switch ll.Policy {
+	case PolicyTwoQ:
+		return twoQAccess(ll.adaptive.twoQ, key, value, ttl)
+	case PolicyARC:
+		return arcAccess(ll.adaptive.arc, key, value, ttl)
+	default:
+		return nil, errors.New("Access requires PolicyTwoQ or PolicyARC")
+	}
+}
+
+func twoQAccess[K comparable, V any](s *twoQState[K, V], key K, value V, ttl int64) (*Node[K, V], error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if entry, ok := s.index[key]; ok {
+		// Already resident. Only a hit in am's LRU order matters;
+		// a1In is a FIFO and is left alone until it overflows.
+		if entry.list == s.am {
+			_ = Touch(s.am, entry.node)
+		}
+		return entry.node, nil
+	}
+
+	if ghost, ok := findByKey(s.a1Out, key); ok {
+		if _, err := RemoveNode(s.a1Out, ghost, EvictReplaced); err != nil {
+			return nil, err
+		}
+		node, err := Insert(s.am, key, value, ttl)
+		if err != nil {
+			return nil, err
+		}
+		s.index[key] = residentEntry[K, V]{list: s.am, node: node}
+
+		if s.am.Size > s.amCap {
+			victim, err := RemoveLast(s.am, EvictCapacity)
+			if err != nil {
+				return nil, err
+			}
+			delete(s.index, victim.Key)
+		}
+
+		return node, nil
+	}
+
+	node, err := Insert(s.a1In, key, value, ttl)
+	if err != nil {
+		return nil, err
+	}
+	s.index[key] = residentEntry[K, V]{list: s.a1In, node: node}
+
+	if s.a1In.Size > s.a1InCap {
+		victim, err := RemoveLast(s.a1In, EvictCapacity)
+		if err != nil {
+			return nil, err
+		}
+		// victim leaves index entirely, not just a1In: index only
+		// tracks resident entries (a1In/am), and a1Out's ghost
+		// entries are found by key via findByKey, same as b1/b2.
+		// Keeping a ghost in index would make the hit check above
+		// short-circuit a ghost's promotion into am.
+		delete(s.index, victim.Key)
+
+		var zero V
+		if _, err := Insert(s.a1Out, victim.Key, zero, -1); err != nil {
+			return nil, err
+		}
+		if s.a1Out.Size > s.a1OutCap {
+			if _, err := RemoveLast(s.a1Out, EvictCapacity); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return node, nil
+}
+
+// findByKey walks ghost, which only ever holds key-only ghost
+// entries, looking for key. Ghost lists are small by
+// construction (a1OutCap, or capacity for B1/B2), so a linear
+// scan is cheap relative to the lock churn an index would add.
+func findByKey[K comparable, V any](ghost *List[K, V], key K) (*Node[K, V], bool) {
+	ghost.Mux.Lock()
+	defer ghost.Mux.Unlock()
+
+	for node := ghost.Head.Next; node != ghost.Tail; node = node.Next {
+		if node.Key == key {
+			return node, true
+		}
+	}
+	return nil, false
+}
+
+// arcState is the Adaptive Replacement Cache's bookkeeping: T1
+// and T2 are resident lists for recency and frequency, B1 and B2
+// are ghost lists of keys recently evicted from T1 and T2, and p
+// is the adaptive target size for T1, nudged by ghost hits per
+// Section 3.3 of the ARC paper.
+type arcState[K comparable, V any] struct {
+	mux sync.Mutex
+
+	capacity int32
+	p        int32
+
+	t1, t2 *List[K, V]
+	b1, b2 *List[K, V]
+
+	index map[K]residentEntry[K, V] // resident nodes, in t1 or t2
+}
+
+func newARCState[K comparable, V any](capacity int32) *arcState[K, V] {
+	return &arcState[K, V]{
+		capacity: capacity,
+		t1:       InitList[K, V](PolicyLRU, 0),
+		t2:       InitList[K, V](PolicyLRU, 0),
+		b1:       InitList[K, V](PolicyLRU, 0),
+		b2:       InitList[K, V](PolicyLRU, 0),
+		index:    make(map[K]residentEntry[K, V]),
+	}
+}
+
+func arcAccess[K comparable, V any](s *arcState[K, V], key K, value V, ttl int64) (*Node[K, V], error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if entry, ok := s.index[key]; ok {
+		// Resident hit: promote to T2, the frequency list.
+		if _, err := RemoveNode(entry.list, entry.node, EvictReplaced); err != nil {
+			return nil, err
+		}
+		promoted, err := Insert(s.t2, key, entry.node.Value, entry.node.TTL)
+		if err != nil {
+			return nil, err
+		}
+		s.index[key] = residentEntry[K, V]{list: s.t2, node: promoted}
+		return promoted, nil
+	}
+
+	if ghost, ok := findByKey(s.b1, key); ok {
+		delta := int32(1)
+		if s.b1.Size > 0 {
+			delta = maxInt32(s.b2.Size/s.b1.Size, 1)
+		}
+		s.p = minInt32(s.capacity, s.p+delta)
+		if _, err := RemoveNode(s.b1, ghost, EvictReplaced); err != nil {
+			return nil, err
+		}
+		if err := s.replace(false); err != nil {
+			return nil, err
+		}
+		return s.insertResident(s.t2, key, value, ttl)
+	}
+
+	if ghost, ok := findByKey(s.b2, key); ok {
+		delta := int32(1)
+		if s.b2.Size > 0 {
+			delta = maxInt32(s.b1.Size/s.b2.Size, 1)
+		}
+		s.p = maxInt32(0, s.p-delta)
+		if _, err := RemoveNode(s.b2, ghost, EvictReplaced); err != nil {
+			return nil, err
+		}
+		if err := s.replace(true); err != nil {
+			return nil, err
+		}
+		return s.insertResident(s.t2, key, value, ttl)
+	}
+
+	// True miss: make room if T1+T2 (plus their ghosts) has
+	// reached capacity, then insert at T1's head.
+	if s.t1.Size+s.b1.Size == s.capacity {
+		if s.t1.Size < s.capacity {
+			if _, err := RemoveLast(s.b1, EvictCapacity); err != nil {
+				return nil, err
+			}
+			if err := s.replace(false); err != nil {
+				return nil, err
+			}
+		} else {
+			victim, err := RemoveLast(s.t1, EvictCapacity)
+			if err != nil {
+				return nil, err
+			}
+			delete(s.index, victim.Key)
+		}
+	} else if s.t1.Size+s.b1.Size < s.capacity && s.t1.Size+s.t2.Size+s.b1.Size+s.b2.Size >= s.capacity {
+		if s.t1.Size+s.t2.Size+s.b1.Size+s.b2.Size == 2*s.capacity {
+			if _, err := RemoveLast(s.b2, EvictCapacity); err != nil {
+				return nil, err
+			}
+		}
+		if err := s.replace(false); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.insertResident(s.t1, key, value, ttl)
+}
+
+func (s *arcState[K, V]) insertResident(home *List[K, V], key K, value V, ttl int64) (*Node[K, V], error) {
+	node, err := Insert(home, key, value, ttl)
+	if err != nil {
+		return nil, err
+	}
+	s.index[key] = residentEntry[K, V]{list: home, node: node}
+	return node, nil
+}
+
+// replace evicts from T1 or T2 into the matching ghost list,
+// favoring T1 unless it has shrunk below the adaptive target p.
+// inB2Hit must be the caller's own record of whether this replace
+// is happening because of a B2 ghost hit: by the time replace
+// runs, the B2 entry that triggered it has already been removed
+// by the caller, so replace can no longer rediscover that by
+// searching B2 itself.
+func (s *arcState[K, V]) replace(inB2Hit bool) error {
+	var zero V
+	if s.t1.Size > 0 && (s.t1.Size > s.p || (s.t1.Size == s.p && inB2Hit)) {
+		victim, err := RemoveLast(s.t1, EvictCapacity)
+		if err != nil {
+			return err
+		}
+		delete(s.index, victim.Key)
+		_, err = Insert(s.b1, victim.Key, zero, -1)
+		return err
+	}
+	if s.t2.Size > 0 {
+		victim, err := RemoveLast(s.t2, EvictCapacity)
+		if err != nil {
+			return err
+		}
+		delete(s.index, victim.Key)
+		_, err = Insert(s.b2, victim.Key, zero, -1)
+		return err
+	}
+	return nil
+}
+
+func maxInt32(a, b int32) int32 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minInt32(a, b int32) int32 {
+	if a < b {
+		return a
+	}
+	return b
+}
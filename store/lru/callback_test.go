@@ -0,0 +1,114 @@
+/*
+ * Copyright (c) 2020, Jake Grogan
+ * All rights reserved.
+ *
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ *  * Redistributions of source code must retain the above copyright notice, this
+ *    list of conditions and the following disclaimer.
+ *
+ *  * Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ *
+ *  * Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from
+ *    this software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+ * DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+ * FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+ * DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+ * SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+ * CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+ * OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+ * OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package lru
+
+import (
+	"testing"
+	"time"
+)
+
+// TestOnEvictReasonCodes exercises every reason code OnEvict can
+// be called with: EvictCapacity from RemoveLast, EvictExplicit
+// from a caller-driven RemoveNode, and EvictReplaced from a
+// same-key Insert, in the order they actually fired.
+func TestOnEvictReasonCodes(t *testing.T) {
+	var got []EvictReason
+	ll := InitList[string, int](PolicyLRU, 0)
+	ll.OnEvict = func(node *Node[string, int], reason EvictReason) {
+		got = append(got, reason)
+	}
+
+	if _, err := Insert(ll, "a", 1, -1); err != nil {
+		t.Fatalf("Insert(a): %v", err)
+	}
+	if _, err := Insert(ll, "b", 2, -1); err != nil {
+		t.Fatalf("Insert(b): %v", err)
+	}
+
+	if _, err := RemoveLast(ll, EvictCapacity); err != nil {
+		t.Fatalf("RemoveLast: %v", err)
+	}
+
+	node, ok := Get(ll, "b")
+	if !ok {
+		t.Fatalf("expected b to still be present")
+	}
+	if _, err := RemoveNode(ll, node, EvictExplicit); err != nil {
+		t.Fatalf("RemoveNode: %v", err)
+	}
+
+	if _, err := Insert(ll, "c", 3, -1); err != nil {
+		t.Fatalf("Insert(c): %v", err)
+	}
+	if _, err := Insert(ll, "c", 4, -1); err != nil {
+		t.Fatalf("Insert(c) again: %v", err)
+	}
+
+	want := []EvictReason{EvictCapacity, EvictExplicit, EvictReplaced}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d OnEvict calls, got %d: %v", len(want), len(got), got)
+	}
+	for i, reason := range want {
+		if got[i] != reason {
+			t.Fatalf("call %d: expected reason %v, got %v", i, reason, got[i])
+		}
+	}
+}
+
+// TestOnEvictRunsOutsideListMux pins down the doc comment's
+// promise that OnEvict fires after List.Mux is released: a
+// callback that calls back into the same list (e.g. to flush the
+// evicted entry elsewhere before re-inserting) must not deadlock
+// against its own eviction.
+func TestOnEvictRunsOutsideListMux(t *testing.T) {
+	ll := InitList[string, int](PolicyLRU, 0)
+	if _, err := Insert(ll, "a", 1, -1); err != nil {
+		t.Fatalf("Insert(a): %v", err)
+	}
+
+	done := make(chan struct{})
+	ll.OnEvict = func(node *Node[string, int], reason EvictReason) {
+		if _, err := Insert(ll, "b", 2, -1); err != nil {
+			t.Errorf("reentrant Insert from OnEvict: %v", err)
+		}
+		close(done)
+	}
+
+	if _, err := RemoveLast(ll, EvictCapacity); err != nil {
+		t.Fatalf("RemoveLast: %v", err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("OnEvict's reentrant call into the list deadlocked; OnEvict must run outside List.Mux")
+	}
+}
@@ -37,12 +37,73 @@ import (
 	"time"
 )
 
-type Node struct {
+// EvictionPolicy selects the replacement algorithm a List uses
+// when it is constructed via InitList. PolicyLRU and PolicySIEVE
+// share the same Node/List storage and differ only in what Touch
+// and Evict do with it; PolicyTwoQ and PolicyARC keep their own
+// sub-lists and are driven through Access instead.
+type EvictionPolicy int
+
+const (
+	// PolicyLRU moves a node to the head of the list on every
+	// Touch and evicts from the tail. This is the original,
+	// strict LRU behavior of this package.
+	PolicyLRU EvictionPolicy = iota
+
+	// PolicySIEVE flips a per-node "visited" bit on Touch instead
+	// of mutating the list, and evicts by sweeping a single hand
+	// pointer from the tail toward the head, clearing visited
+	// bits it passes over until it finds an unvisited node.
+	PolicySIEVE
+
+	// PolicyTwoQ implements the 2Q algorithm: a small FIFO of
+	// recent one-hit entries backed by a ghost FIFO of their
+	// keys, feeding a main LRU. See Access for the entry point;
+	// Touch/Evict are not used under this policy.
+	PolicyTwoQ
+
+	// PolicyARC implements the Adaptive Replacement Cache
+	// algorithm: recency and frequency resident lists (T1, T2)
+	// each backed by a ghost list (B1, B2), with a target size
+	// for T1 that adapts to ghost hits. See Access for the entry
+	// point; Touch/Evict are not used under this policy.
+	PolicyARC
+)
+
+// EvictReason distinguishes why an entry left a List, so an
+// OnEvict hook can tell a capacity-driven eviction apart from a
+// caller-requested removal.
+type EvictReason int
+
+const (
+	// EvictCapacity means the list removed its least-valuable
+	// entry to make room, via Evict, RemoveLast, or the SIEVE
+	// hand sweep.
+	EvictCapacity EvictReason = iota
+
+	// EvictExplicit means a caller asked for this specific key to
+	// be removed, independent of capacity.
+	EvictExplicit
+
+	// EvictTTL means a TTL sweeper removed the entry because it
+	// expired.
+	EvictTTL
+
+	// EvictReplaced means the entry was removed as a side effect
+	// of relocating it elsewhere (e.g. a 2Q/ARC promotion out of
+	// a ghost list), not because it left the cache.
+	EvictReplaced
+)
+
+// Node is one entry of a doubly linked List, keyed by K and
+// holding a value of type V. K and V are fixed when the owning
+// List is constructed via InitList.
+type Node[K comparable, V any] struct {
 	// Key of the key-value pair
-	Key string
+	Key K
 
 	// Value of the key-value pair
-	Value interface{}
+	Value V
 
 	// TTL is the time-to-live for the key-value pair
 	TTL int64
@@ -51,40 +112,93 @@ type Node struct {
 	// into the cache.
 	CreatedAt int64
 
+	// Visited is the SIEVE "visited" bit. It is set by Touch and
+	// cleared by the hand as it sweeps past the node looking for
+	// an eviction victim. Unused under PolicyLRU.
+	Visited bool `json:"visited"`
+
 	// Prev points to the previous node in the doubly
 	// linked list. Omit this from snapshot serialization.
-	Prev *Node `json:"-"`
+	Prev *Node[K, V] `json:"-"`
 
 	// Next points to the next node in the doubly linked
 	// list. Omit this from snapshot serialization.
-	Next *Node `json:"-"`
+	Next *Node[K, V] `json:"-"`
 
 	// Mux is a mutex lock.
 	Mux sync.Mutex
 }
 
-type List struct {
+// List is a doubly linked, Key-indexed list of Node[K, V]
+// entries under a single EvictionPolicy.
+type List[K comparable, V any] struct {
 	// Head is the head node. It is a special case node.
 	// It does not get populated and is a reference node
 	// for accessing the most recently used key-value pair.
-	Head *Node `json:"-"`
+	Head *Node[K, V] `json:"-"`
 
 	// Tail is the tail node. It is a special case node.
 	// It does not get populated and is a reference node
 	// for accessing the least recently used key-value pair.
-	Tail *Node `json:"-"`
+	Tail *Node[K, V] `json:"-"`
 
 	// Size is the size of the list.
 	Size int32
-	Mux  sync.Mutex
+
+	// Policy is the eviction policy this list enforces. It is
+	// set once at InitList time and read by Touch and Evict.
+	Policy EvictionPolicy
+
+	// Capacity is the maximum number of resident entries this
+	// list should hold. It is only consulted by policies that
+	// partition capacity across sub-lists (2Q, ARC); PolicyLRU
+	// and PolicySIEVE leave capacity enforcement to the caller,
+	// as before.
+	Capacity int32
+
+	// Hand is the SIEVE hand pointer. It starts at the tail and
+	// only moves during Evict. Unused under PolicyLRU.
+	Hand *Node[K, V] `json:"-"`
+
+	// OnEvict, if set, is called whenever RemoveLast or RemoveNode
+	// unlinks an entry from this list, with the reason it left.
+	// It fires after the list mutation but before RemoveLast or
+	// RemoveNode returns to their caller, and it runs outside
+	// List.Mux so it may safely call back into this list (e.g. to
+	// flush the entry to the append-only log) without
+	// deadlocking. A nil OnEvict, the default, is a no-op.
+	OnEvict func(node *Node[K, V], reason EvictReason) `json:"-"`
+
+	// adaptive holds the extra sub-lists PolicyTwoQ and PolicyARC
+	// need. It is nil under PolicyLRU and PolicySIEVE.
+	adaptive *adaptiveState[K, V] `json:"-"`
+
+	// index maps a key to its node, so Get and the eviction paths
+	// below don't need a linear scan. It is maintained by Insert,
+	// RemoveLast, and RemoveNode.
+	index map[K]*Node[K, V] `json:"-"`
+
+	// metrics is the sink Insert/Get/RemoveLast/RemoveNode report
+	// to. It is nil unless the list was built with
+	// InitListWithMetrics, in which case every call site below
+	// does a single inlined nil check before reporting.
+	metrics Metrics `json:"-"`
+
+	Mux sync.Mutex
 }
 
-// InitList initializes the doubly-linked list.
-func InitList() *List {
+// InitList initializes the doubly-linked list under the given
+// eviction policy. capacity is only used by policies that
+// partition capacity across sub-lists (2Q, ARC); pass 0 for
+// PolicyLRU or PolicySIEVE.
+func InitList[K comparable, V any](policy EvictionPolicy, capacity int32) *List[K, V] {
+	var zeroKey K
+	var zeroValue V
+
 	// Init the head node
-	headNode := &Node{
-		Key:       "",
-		Value:     "",
+	headNode := &Node[K, V]{
+		Key:       zeroKey,
+		Value:     zeroValue,
 		TTL:       -1,
 		CreatedAt: time.Now().Unix(),
 		Prev:      nil,
@@ -92,9 +206,9 @@ func InitList() *List {
 	}
 
 	// Init the tail node
-	tailNode := &Node{
-		Key:       "",
-		Value:     "",
+	tailNode := &Node[K, V]{
+		Key:       zeroKey,
+		Value:     zeroValue,
 		TTL:       -1,
 		CreatedAt: time.Now().Unix(),
 		Prev:      nil,
@@ -102,28 +216,58 @@ func InitList() *List {
 	}
 
 	// Init the doubly-linked list
-	list := &List{
-		Head: headNode,
-		Tail: tailNode,
-		Size: int32(0),
+	list := &List[K, V]{
+		Head:     headNode,
+		Tail:     tailNode,
+		Size:     int32(0),
+		Policy:   policy,
+		Capacity: capacity,
+		index:    make(map[K]*Node[K, V]),
 	}
 
 	// Set correct pointers for head and tail nodes.1
 	list.Head.Next = list.Tail
 	list.Tail.Prev = list.Head
 
+	// SIEVE's hand starts at the tail and only ever walks toward
+	// the head from there.
+	if policy == PolicySIEVE {
+		list.Hand = list.Tail
+	}
+
+	if policy == PolicyTwoQ {
+		list.adaptive = &adaptiveState[K, V]{twoQ: newTwoQState[K, V](capacity)}
+	}
+	if policy == PolicyARC {
+		list.adaptive = &adaptiveState[K, V]{arc: newARCState[K, V](capacity)}
+	}
+
 	return list
 }
 
 // Insert will insert key-value pairs nodes into the doubly
-// linked list.
-func Insert(ll *List, key string, value interface{}, ttl int64) (*Node, error) {
+// linked list. If key already has a resident node, that node is
+// unlinked first: Insert never leaves two nodes for the same key
+// in the list, one live in index and one orphaned but still
+// linked, waiting to corrupt index the day it's finally evicted.
+// The replaced node, if any, is reported to OnEvict with
+// EvictReplaced, same as a 2Q/ARC promotion.
+func Insert[K comparable, V any](ll *List[K, V], key K, value V, ttl int64) (*Node[K, V], error) {
+	start := time.Now()
+
 	// Lock access to the list
 	ll.Mux.Lock()
-	defer ll.Mux.Unlock()
+
+	var replaced *Node[K, V]
+	if existing, ok := ll.index[key]; ok {
+		existing.Prev.Next = existing.Next
+		existing.Next.Prev = existing.Prev
+		atomic.AddInt32(&ll.Size, -1)
+		replaced = existing
+	}
 
 	// Init the new node
-	newNode := &Node{
+	newNode := &Node[K, V]{
 		Key:       key,
 		Value:     value,
 		TTL:       ttl,
@@ -139,19 +283,60 @@ func Insert(ll *List, key string, value interface{}, ttl int64) (*Node, error) {
 	ll.Head.Next = newNode      // Point Head to newNode
 	newNode.Next.Prev = newNode // Point the old "Most Recent" to the new node
 
+	ll.index[key] = newNode
+
 	// Atomically increment the size.
-	atomic.AddInt32(&ll.Size, 1)
+	size := atomic.AddInt32(&ll.Size, 1)
+	ll.Mux.Unlock()
+
+	if replaced != nil && ll.OnEvict != nil {
+		ll.OnEvict(replaced, EvictReplaced)
+	}
+
+	ll.incCounter("lru.insert", 1)
+	ll.setGauge("lru.size", float64(size))
+	ll.observeHistogram("lru.insert.latency_seconds", time.Since(start).Seconds())
 
 	return newNode, nil
 }
 
+// Get looks up key and, on a hit, records the access via Touch.
+// It reports a hit or miss counter and an lru.get.latency_seconds
+// sample to the list's metrics sink, if one is configured. PolicyTwoQ and
+// PolicyARC don't support Get: a miss there needs a value to
+// insert, which Get doesn't take, so use Access for those
+// policies instead.
+func Get[K comparable, V any](ll *List[K, V], key K) (*Node[K, V], bool) {
+	if ll.Policy == PolicyTwoQ || ll.Policy == PolicyARC {
+		return nil, false
+	}
+
+	start := time.Now()
+
+	ll.Mux.Lock()
+	node, ok := ll.index[key]
+	ll.Mux.Unlock()
+
+	if !ok {
+		ll.incCounter("lru.miss", 1)
+		ll.observeHistogram("lru.get.latency_seconds", time.Since(start).Seconds())
+		return nil, false
+	}
+
+	_ = Touch(ll, node)
+	ll.incCounter("lru.hit", 1)
+	ll.observeHistogram("lru.get.latency_seconds", time.Since(start).Seconds())
+	return node, true
+}
+
 // RemoveLast removes the least recently used item in the list.
-func RemoveLast(ll *List) (*Node, error) {
+// reason is forwarded to List.OnEvict, if one is registered.
+func RemoveLast[K comparable, V any](ll *List[K, V], reason EvictReason) (*Node[K, V], error) {
 	// Lock access
 	ll.Mux.Lock()
-	defer ll.Mux.Unlock()
 
 	if ll.Size == 0 {
+		ll.Mux.Unlock()
 		return nil, errors.New("List is empty")
 	}
 	// Update reference pointers
@@ -159,41 +344,59 @@ func RemoveLast(ll *List) (*Node, error) {
 
 	nodeToRemove.Prev.Next = ll.Tail
 	ll.Tail.Prev = nodeToRemove.Prev
+	delete(ll.index, nodeToRemove.Key)
 
-	atomic.AddInt32(&ll.Size, -1)
+	size := atomic.AddInt32(&ll.Size, -1)
+	ll.Mux.Unlock()
+
+	if ll.OnEvict != nil {
+		ll.OnEvict(nodeToRemove, reason)
+	}
+
+	ll.incCounter("lru.evict", 1)
+	ll.setGauge("lru.size", float64(size))
 
 	return nodeToRemove, nil
 }
 
-// RemoveNode removes a specific node from the list.
-func RemoveNode(ll *List, node *Node) (*Node, error) {
+// RemoveNode removes a specific node from the list. reason is
+// forwarded to List.OnEvict, if one is registered.
+func RemoveNode[K comparable, V any](ll *List[K, V], node *Node[K, V], reason EvictReason) (*Node[K, V], error) {
 	ll.Mux.Lock()
 	if ll.Size == 0 {
 		ll.Mux.Unlock()
 		return nil, errors.New("List is empty")
 	} else if ll.Size == 1 {
 		ll.Mux.Unlock()
-		returnNode, _ := RemoveLast(ll)
+		returnNode, _ := RemoveLast(ll, reason)
 		return returnNode, nil
 	}
 	ll.Mux.Unlock()
 
 	ll.Mux.Lock()
-	defer ll.Mux.Unlock()
 
 	prevNode := node.Prev
 	nextNode := node.Next
 
 	prevNode.Next = node.Next
 	nextNode.Prev = node.Prev
+	delete(ll.index, node.Key)
+
+	size := atomic.AddInt32(&ll.Size, -1)
+	ll.Mux.Unlock()
+
+	if ll.OnEvict != nil {
+		ll.OnEvict(node, reason)
+	}
 
-	atomic.AddInt32(&ll.Size, -1)
+	ll.incCounter("lru.evict", 1)
+	ll.setGauge("lru.size", float64(size))
 
 	return node, nil
 }
 
 // Returns the last node in the list
-func GetLastNode(ll *List) (*Node, error) {
+func GetLastNode[K comparable, V any](ll *List[K, V]) (*Node[K, V], error) {
 	ll.Mux.Lock()
 	if ll.Size == int32(0) {
 		ll.Mux.Unlock()
@@ -204,3 +407,105 @@ func GetLastNode(ll *List) (*Node, error) {
 	nodeToGet := ll.Tail.Prev
 	return nodeToGet, nil
 }
+
+// moveToHead detaches node from wherever it currently sits and
+// re-links it directly after Head, without allocating a new
+// node. It is the LRU promotion step used by Touch.
+func moveToHead[K comparable, V any](ll *List[K, V], node *Node[K, V]) {
+	ll.Mux.Lock()
+	defer ll.Mux.Unlock()
+
+	node.Prev.Next = node.Next
+	node.Next.Prev = node.Prev
+
+	node.Prev = ll.Head
+	node.Next = ll.Head.Next
+	ll.Head.Next = node
+	node.Next.Prev = node
+}
+
+// Touch records an access to node. Under PolicyLRU it promotes
+// the node to the head of the list. Under PolicySIEVE it only
+// sets the node's visited bit, locking the node itself rather
+// than the list, so reads under SIEVE never contend with each
+// other on list structure.
+func Touch[K comparable, V any](ll *List[K, V], node *Node[K, V]) error {
+	switch ll.Policy {
+	case PolicySIEVE:
+		node.Mux.Lock()
+		node.Visited = true
+		node.Mux.Unlock()
+		return nil
+	case PolicyTwoQ, PolicyARC:
+		return errors.New("Touch is not supported under PolicyTwoQ/PolicyARC; use Access")
+	default:
+		if node == ll.Head || node == ll.Tail {
+			return errors.New("cannot touch a sentinel node")
+		}
+		moveToHead(ll, node)
+		return nil
+	}
+}
+
+// Evict removes this list's eviction victim according to its
+// policy and returns the removed node. Under PolicyLRU this is
+// the tail. Under PolicySIEVE the hand sweeps from its current
+// position toward the head, clearing visited bits until it finds
+// an unvisited node, then evicts that node and leaves the hand
+// on its predecessor.
+func Evict[K comparable, V any](ll *List[K, V]) (*Node[K, V], error) {
+	switch ll.Policy {
+	case PolicySIEVE:
+		return evictSIEVE(ll)
+	case PolicyTwoQ, PolicyARC:
+		return nil, errors.New("Evict is not supported under PolicyTwoQ/PolicyARC; eviction happens inside Access")
+	default:
+		return RemoveLast(ll, EvictCapacity)
+	}
+}
+
+func evictSIEVE[K comparable, V any](ll *List[K, V]) (*Node[K, V], error) {
+	ll.Mux.Lock()
+	if ll.Size == 0 {
+		ll.Mux.Unlock()
+		return nil, errors.New("List is empty")
+	}
+	hand := ll.Hand
+	if hand == nil || hand == ll.Head || hand == ll.Tail {
+		hand = ll.Tail.Prev
+	}
+
+	// The walk below reads Prev on every node it visits, so it
+	// must hold ll.Mux for the whole traversal, not just to
+	// snapshot the starting hand: Insert/RemoveNode/RemoveLast all
+	// rewrite neighboring Prev/Next under this same lock, and a
+	// hand left to wander unlocked can land on a node a concurrent
+	// removal has already unlinked.
+	var victim *Node[K, V]
+	for {
+		if hand == ll.Head {
+			hand = ll.Tail.Prev
+			continue
+		}
+
+		hand.Mux.Lock()
+		visited := hand.Visited
+		if visited {
+			hand.Visited = false
+		}
+		hand.Mux.Unlock()
+
+		if visited {
+			hand = hand.Prev
+			continue
+		}
+
+		victim = hand
+		break
+	}
+
+	ll.Hand = victim.Prev
+	ll.Mux.Unlock()
+
+	return RemoveNode(ll, victim, EvictCapacity)
+}
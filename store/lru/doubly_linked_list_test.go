@@ -0,0 +1,77 @@
+/*
+ * Copyright (c) 2020, Jake Grogan
+ * All rights reserved.
+ *
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ *  * Redistributions of source code must retain the above copyright notice, this
+ *    list of conditions and the following disclaimer.
+ *
+ *  * Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ *
+ *  * Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from
+ *    this software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+ * DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+ * FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+ * DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+ * SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+ * CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+ * OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+ * OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package lru
+
+import "testing"
+
+// TestSIEVEEvictsUnvisitedBeforeVisited exercises the hand-sweep
+// ordering PolicySIEVE is supposed to give: a Touch'd (visited)
+// entry survives one pass of the hand, while unvisited entries
+// are taken in hand-walk order.
+func TestSIEVEEvictsUnvisitedBeforeVisited(t *testing.T) {
+	ll := InitList[string, int](PolicySIEVE, 0)
+
+	if _, err := Insert(ll, "a", 1, -1); err != nil {
+		t.Fatalf("Insert(a): %v", err)
+	}
+	if _, err := Insert(ll, "b", 2, -1); err != nil {
+		t.Fatalf("Insert(b): %v", err)
+	}
+	if _, err := Insert(ll, "c", 3, -1); err != nil {
+		t.Fatalf("Insert(c): %v", err)
+	}
+
+	// Mark b visited; it must survive the next Evict and only be
+	// considered again on a later pass of the hand.
+	if _, ok := Get(ll, "b"); !ok {
+		t.Fatalf("expected b to be present")
+	}
+
+	victim, err := Evict(ll)
+	if err != nil {
+		t.Fatalf("first Evict: %v", err)
+	}
+	if victim.Key != "a" {
+		t.Fatalf("expected first evicted key to be a (hand starts at the tail), got %v", victim.Key)
+	}
+
+	victim, err = Evict(ll)
+	if err != nil {
+		t.Fatalf("second Evict: %v", err)
+	}
+	if victim.Key != "c" {
+		t.Fatalf("expected second evicted key to be c (b's visited bit spared it once), got %v", victim.Key)
+	}
+
+	if _, ok := Get(ll, "b"); !ok {
+		t.Fatalf("expected b to still be resident after two evictions")
+	}
+}
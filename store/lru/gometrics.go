@@ -0,0 +1,60 @@
+/*
+ * Copyright (c) 2020, Jake Grogan
+ * All rights reserved.
+ *
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ *  * Redistributions of source code must retain the above copyright notice, this
+ *    list of conditions and the following disclaimer.
+ *
+ *  * Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ *
+ *  * Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from
+ *    this software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+ * DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+ * FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+ * DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+ * SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+ * CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+ * OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+ * OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package lru
+
+import gometrics "github.com/armon/go-metrics"
+
+// GoMetricsSink adapts *gometrics.Metrics (github.com/armon/go-metrics)
+// to this package's Metrics interface, so an operator can scrape
+// hit ratio, eviction rate, list size, and Insert/Get latency through
+// whichever statsd/Prometheus sink go-metrics is already
+// configured with.
+type GoMetricsSink struct {
+	Metrics *gometrics.Metrics
+}
+
+// NewGoMetricsSink wraps an existing *gometrics.Metrics. Pass
+// gometrics.Default() to use the package-level global.
+func NewGoMetricsSink(m *gometrics.Metrics) *GoMetricsSink {
+	return &GoMetricsSink{Metrics: m}
+}
+
+func (g *GoMetricsSink) IncCounter(name string, v float64) {
+	g.Metrics.IncrCounter([]string{name}, float32(v))
+}
+
+func (g *GoMetricsSink) SetGauge(name string, v float64) {
+	g.Metrics.SetGauge([]string{name}, float32(v))
+}
+
+func (g *GoMetricsSink) ObserveHistogram(name string, v float64) {
+	g.Metrics.AddSample([]string{name}, float32(v))
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright (c) 2020, Jake Grogan
+ * All rights reserved.
+ *
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ *  * Redistributions of source code must retain the above copyright notice, this
+ *    list of conditions and the following disclaimer.
+ *
+ *  * Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ *
+ *  * Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from
+ *    this software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+ * DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+ * FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+ * DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+ * SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+ * CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+ * OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+ * OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package lru
+
+import "testing"
+
+// TestInsertReplacesExistingNodeForSameKey pins down that a
+// second Insert for a key that's still resident replaces the old
+// node instead of leaving it linked-but-unreachable: Size and the
+// list's actual node count must agree, the replaced node must be
+// reported via OnEvict as EvictReplaced, and the surviving node
+// must carry the newest value.
+func TestInsertReplacesExistingNodeForSameKey(t *testing.T) {
+	ll := InitList[string, int](PolicyLRU, 0)
+
+	var replaced []*Node[string, int]
+	ll.OnEvict = func(node *Node[string, int], reason EvictReason) {
+		replaced = append(replaced, node)
+		if reason != EvictReplaced {
+			t.Fatalf("expected EvictReplaced, got %v", reason)
+		}
+	}
+
+	if _, err := Insert(ll, "k", 1, -1); err != nil {
+		t.Fatalf("first Insert: %v", err)
+	}
+	if _, err := Insert(ll, "k", 2, -1); err != nil {
+		t.Fatalf("second Insert: %v", err)
+	}
+
+	if ll.Size != 1 {
+		t.Fatalf("expected Size to stay at 1 across a same-key Insert, got %d", ll.Size)
+	}
+	if len(replaced) != 1 || replaced[0].Value != 1 {
+		t.Fatalf("expected OnEvict to fire once for the old value 1, got %+v", replaced)
+	}
+
+	node, ok := Get(ll, "k")
+	if !ok || node.Value != 2 {
+		t.Fatalf("expected k to resolve to the newest value 2, got %+v", node)
+	}
+
+	// Walking the list from Head must reach exactly one node, not
+	// an orphaned first node still linked behind it.
+	count := 0
+	for n := ll.Head.Next; n != ll.Tail; n = n.Next {
+		count++
+	}
+	if count != 1 {
+		t.Fatalf("expected exactly one linked node after a same-key Insert, found %d", count)
+	}
+}
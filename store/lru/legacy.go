@@ -0,0 +1,94 @@
+/*
+ * Copyright (c) 2020, Jake Grogan
+ * All rights reserved.
+ *
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ *  * Redistributions of source code must retain the above copyright notice, this
+ *    list of conditions and the following disclaimer.
+ *
+ *  * Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ *
+ *  * Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from
+ *    this software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+ * DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+ * FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+ * DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+ * SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+ * CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+ * OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+ * OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package lru
+
+// StringNode and StringList instantiate the generic list at the
+// shape this package exposed before Node/List took type
+// parameters: string keys, interface{} values. These names and
+// the wrappers below save callers from writing out
+// Node[string, interface{}]/List[string, interface{}] themselves;
+// they do not preserve the pre-generics call signatures, which
+// have moved on with the rest of the package (e.g. RemoveLast and
+// RemoveNode now take a reason, and so do RemoveLastString and
+// RemoveNodeString). There is no separate compatibility
+// guarantee here beyond the type aliases.
+type StringNode = Node[string, interface{}]
+type StringList = List[string, interface{}]
+
+// InitStringList is InitList[string, interface{}].
+func InitStringList(policy EvictionPolicy, capacity int32) *StringList {
+	return InitList[string, interface{}](policy, capacity)
+}
+
+// InitStringListWithMetrics is InitListWithMetrics[string, interface{}].
+func InitStringListWithMetrics(policy EvictionPolicy, capacity int32, sink Metrics) *StringList {
+	return InitListWithMetrics[string, interface{}](policy, capacity, sink)
+}
+
+// GetString is Get[string, interface{}].
+func GetString(ll *StringList, key string) (*StringNode, bool) {
+	return Get(ll, key)
+}
+
+// InsertString is Insert[string, interface{}].
+func InsertString(ll *StringList, key string, value interface{}, ttl int64) (*StringNode, error) {
+	return Insert(ll, key, value, ttl)
+}
+
+// RemoveLastString is RemoveLast[string, interface{}].
+func RemoveLastString(ll *StringList, reason EvictReason) (*StringNode, error) {
+	return RemoveLast(ll, reason)
+}
+
+// RemoveNodeString is RemoveNode[string, interface{}].
+func RemoveNodeString(ll *StringList, node *StringNode, reason EvictReason) (*StringNode, error) {
+	return RemoveNode(ll, node, reason)
+}
+
+// GetLastNodeString is GetLastNode[string, interface{}].
+func GetLastNodeString(ll *StringList) (*StringNode, error) {
+	return GetLastNode(ll)
+}
+
+// TouchString is Touch[string, interface{}].
+func TouchString(ll *StringList, node *StringNode) error {
+	return Touch(ll, node)
+}
+
+// EvictString is Evict[string, interface{}].
+func EvictString(ll *StringList) (*StringNode, error) {
+	return Evict(ll)
+}
+
+// AccessString is Access[string, interface{}].
+func AccessString(ll *StringList, key string, value interface{}, ttl int64) (*StringNode, error) {
+	return Access(ll, key, value, ttl)
+}
@@ -0,0 +1,137 @@
+/*
+ * Copyright (c) 2020, Jake Grogan
+ * All rights reserved.
+ *
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ *  * Redistributions of source code must retain the above copyright notice, this
+ *    list of conditions and the following disclaimer.
+ *
+ *  * Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ *
+ *  * Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from
+ *    this software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+ * DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+ * FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+ * DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+ * SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+ * CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+ * OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+ * OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package lru
+
+import "sync"
+
+// Metrics is the instrumentation sink a List reports to: insert
+// and evict counts, hit/miss counts, list size, and the latency of
+// Insert and Get (RemoveLast and RemoveNode report no latency).
+// Implementations must be safe for concurrent use, since
+// List calls them from whichever goroutine happens to be doing
+// the Insert/Get/RemoveLast/RemoveNode.
+type Metrics interface {
+	IncCounter(name string, v float64)
+	SetGauge(name string, v float64)
+	ObserveHistogram(name string, v float64)
+}
+
+// InitListWithMetrics is InitList with a Metrics sink attached.
+// Pass a nil sink to get InitList's behavior back: every report
+// call site is a single inlined nil check, so an uninstrumented
+// list pays no virtual-call overhead on its hot paths.
+func InitListWithMetrics[K comparable, V any](policy EvictionPolicy, capacity int32, sink Metrics) *List[K, V] {
+	ll := InitList[K, V](policy, capacity)
+	ll.metrics = sink
+	return ll
+}
+
+func (ll *List[K, V]) incCounter(name string, v float64) {
+	if ll.metrics == nil {
+		return
+	}
+	ll.metrics.IncCounter(name, v)
+}
+
+func (ll *List[K, V]) setGauge(name string, v float64) {
+	if ll.metrics == nil {
+		return
+	}
+	ll.metrics.SetGauge(name, v)
+}
+
+func (ll *List[K, V]) observeHistogram(name string, v float64) {
+	if ll.metrics == nil {
+		return
+	}
+	ll.metrics.ObserveHistogram(name, v)
+}
+
+// InMemoryMetrics is the default Metrics implementation: it just
+// accumulates counters, gauges, and histogram samples in memory,
+// for tests and for operators who don't want a statsd/Prometheus
+// dependency.
+type InMemoryMetrics struct {
+	mux        sync.Mutex
+	counters   map[string]float64
+	gauges     map[string]float64
+	histograms map[string][]float64
+}
+
+// NewInMemoryMetrics constructs an empty InMemoryMetrics.
+func NewInMemoryMetrics() *InMemoryMetrics {
+	return &InMemoryMetrics{
+		counters:   make(map[string]float64),
+		gauges:     make(map[string]float64),
+		histograms: make(map[string][]float64),
+	}
+}
+
+func (m *InMemoryMetrics) IncCounter(name string, v float64) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.counters[name] += v
+}
+
+func (m *InMemoryMetrics) SetGauge(name string, v float64) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.gauges[name] = v
+}
+
+func (m *InMemoryMetrics) ObserveHistogram(name string, v float64) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.histograms[name] = append(m.histograms[name], v)
+}
+
+// Counter returns the current value of a counter reported via
+// IncCounter.
+func (m *InMemoryMetrics) Counter(name string) float64 {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	return m.counters[name]
+}
+
+// Gauge returns the last value reported via SetGauge.
+func (m *InMemoryMetrics) Gauge(name string) float64 {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	return m.gauges[name]
+}
+
+// Histogram returns every sample reported via ObserveHistogram,
+// in the order they arrived.
+func (m *InMemoryMetrics) Histogram(name string) []float64 {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	return append([]float64(nil), m.histograms[name]...)
+}